@@ -8,17 +8,63 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 
 	kapi "k8s.io/api/core/v1"
 	knet "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	informerfactory "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 )
 
+// watchHandlerErrorsTotal counts errors reported via handleError, labeled by resource type and event
+var watchHandlerErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Subsystem: "ovnkube",
+	Name:      "watch_handler_errors_total",
+	Help:      "Number of errors encountered while dispatching or syncing watch factory events.",
+}, []string{"resource", "event"})
+
+func init() {
+	prometheus.MustRegister(watchHandlerErrorsTotal)
+}
+
+// watchError wraps an error with the resource type and event that produced it
+type watchError struct {
+	resource string
+	event    string
+	err      error
+}
+
+func (e *watchError) Error() string { return e.err.Error() }
+
+func newWatchError(objType reflect.Type, event string, err error) error {
+	return &watchError{resource: objType.String(), event: event, err: err}
+}
+
+// handleError records err in watchHandlerErrorsTotal and forwards it to utilruntime.HandleError
+func handleError(err error) {
+	resource, event := "unknown", "unknown"
+	if we, ok := err.(*watchError); ok {
+		resource, event = we.resource, we.event
+	}
+	watchHandlerErrorsTotal.WithLabelValues(resource, event).Inc()
+	utilruntime.HandleError(err)
+}
+
+// SyncHandler is the retryable per-key callback used by queued informers; see newQueuedInformer
+type SyncHandler func(key string, obj interface{}) error
+
 // Handler represents an event handler and is private to the factory module
 type Handler struct {
 	base cache.FilteringResourceEventHandler
@@ -77,6 +123,11 @@ type informer struct {
 	inf      cache.SharedIndexInformer
 	handlers map[uint64]*Handler
 	events   []chan *event
+
+	// queue and syncHandler are only set for informers built by newQueuedInformer
+	queue       workqueue.RateLimitingInterface
+	syncHandler SyncHandler
+	maxRetries  int
 }
 
 func (i *informer) forEachQueuedHandler(f func(h *Handler)) {
@@ -98,7 +149,7 @@ func (i *informer) forEachHandler(obj interface{}, f func(h *Handler)) {
 
 	objType := reflect.TypeOf(obj)
 	if objType != i.oType {
-		logrus.Errorf("object type %v did not match expected %v", objType, i.oType)
+		handleError(newWatchError(i.oType, "dispatch", fmt.Errorf("object type %v did not match expected %v", objType, i.oType)))
 		return
 	}
 
@@ -131,6 +182,8 @@ func (i *informer) removeHandler(handler *Handler) error {
 	logrus.Debugf("sending %v event handler %d for removal", i.oType, handler.id)
 
 	go func() {
+		defer utilruntime.HandleCrash()
+
 		i.Lock()
 		defer i.Unlock()
 		if _, ok := i.handlers[handler.id]; ok {
@@ -175,7 +228,7 @@ func (i *informer) processEvents(events chan *event, stopChan <-chan struct{}) {
 func (i *informer) enqueueEvent(oldObj, obj interface{}, kind eventKind) {
 	meta, err := getObjectMeta(i.oType, obj)
 	if err != nil {
-		logrus.Errorf("object has no meta: %v", err)
+		handleError(newWatchError(i.oType, "meta", err))
 		return
 	}
 
@@ -228,7 +281,7 @@ func (i *informer) newFederatedQueuedHandler() cache.ResourceEventHandlerFuncs {
 		DeleteFunc: func(obj interface{}) {
 			realObj, err := ensureObjectOnDelete(obj, i.oType)
 			if err != nil {
-				logrus.Errorf(err.Error())
+				handleError(newWatchError(i.oType, "delete", err))
 				return
 			}
 			i.enqueueEvent(nil, realObj, deleteEvent)
@@ -251,7 +304,7 @@ func (i *informer) newFederatedHandler() cache.ResourceEventHandlerFuncs {
 		DeleteFunc: func(obj interface{}) {
 			realObj, err := ensureObjectOnDelete(obj, i.oType)
 			if err != nil {
-				logrus.Errorf(err.Error())
+				handleError(newWatchError(i.oType, "delete", err))
 				return
 			}
 			i.forEachHandler(realObj, func(h *Handler) {
@@ -274,6 +327,10 @@ func (i *informer) shutdown() {
 		close(i.events[idx])
 		i.events[idx] = nil
 	}
+
+	if i.queue != nil {
+		i.queue.ShutDown()
+	}
 }
 
 func newBaseInformer(oType reflect.Type, sharedInformer cache.SharedIndexInformer) *informer {
@@ -290,25 +347,123 @@ func newInformer(oType reflect.Type, sharedInformer cache.SharedIndexInformer) *
 	return i
 }
 
-func newQueuedInformer(oType reflect.Type, sharedInformer cache.SharedIndexInformer, stopChan chan struct{}) *informer {
+// newHashedQueuedInformer creates an informer that fans events out across numEventQueues hashed channels
+func newHashedQueuedInformer(oType reflect.Type, sharedInformer cache.SharedIndexInformer, stopChan chan struct{}) *informer {
 	i := newBaseInformer(oType, sharedInformer)
 	i.events = make([]chan *event, numEventQueues)
 	for j := range i.events {
 		i.events[j] = make(chan *event, 1)
-		go i.processEvents(i.events[j], stopChan)
+		events := i.events[j]
+		go func() {
+			defer utilruntime.HandleCrash()
+			i.processEvents(events, stopChan)
+		}()
 	}
 	i.inf.AddEventHandler(i.newFederatedQueuedHandler())
 	return i
 }
 
+// newQueuedInformer creates an informer backed by a client-go RateLimitingQueue that retries failing keys via syncHandler
+func newQueuedInformer(oType reflect.Type, sharedInformer cache.SharedIndexInformer, stopChan chan struct{}, workers int, maxRetries int, syncHandler SyncHandler) *informer {
+	i := newBaseInformer(oType, sharedInformer)
+	i.queue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), oType.String())
+	i.syncHandler = syncHandler
+	i.maxRetries = maxRetries
+
+	federated := i.newFederatedHandler()
+	i.inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			federated.AddFunc(obj)
+			i.enqueueKey(obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			federated.UpdateFunc(oldObj, newObj)
+			i.enqueueKey(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			federated.DeleteFunc(obj)
+			i.enqueueKey(obj)
+		},
+	})
+
+	for j := 0; j < workers; j++ {
+		go func() {
+			defer utilruntime.HandleCrash()
+			wait.Until(i.runWorker, time.Second, stopChan)
+		}()
+	}
+
+	return i
+}
+
+// enqueueKey adds obj's namespaced key to the informer's rate-limiting queue
+func (i *informer) enqueueKey(obj interface{}) {
+	if i.syncHandler == nil {
+		return
+	}
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		handleError(newWatchError(i.oType, "sync", fmt.Errorf("couldn't get key for object %+v: %v", obj, err)))
+		return
+	}
+	i.queue.Add(key)
+}
+
+func (i *informer) runWorker() {
+	for i.processNextWorkItem() {
+	}
+}
+
+func (i *informer) processNextWorkItem() bool {
+	key, quit := i.queue.Get()
+	if quit {
+		return false
+	}
+	defer i.queue.Done(key)
+
+	obj, _, err := i.inf.GetStore().GetByKey(key.(string))
+	if err != nil {
+		logrus.Errorf("couldn't get %v object for key %q: %v", i.oType, key, err)
+		obj = nil
+	}
+
+	i.handleErr(i.syncHandler(key.(string), obj), key)
+	return true
+}
+
+// handleErr forgets key on success, retries with backoff up to maxRetries, then drops it
+func (i *informer) handleErr(err error, key interface{}) {
+	if err == nil {
+		i.queue.Forget(key)
+		return
+	}
+
+	if i.queue.NumRequeues(key) < i.maxRetries {
+		logrus.Errorf("error syncing %v %q, retrying: %v", i.oType, key, err)
+		i.queue.AddRateLimited(key)
+		return
+	}
+
+	handleError(newWatchError(i.oType, "sync", fmt.Errorf("dropping %q out of the queue after %d retries: %v", key, i.maxRetries, err)))
+	i.queue.Forget(key)
+}
+
 // WatchFactory initializes and manages common kube watches
 type WatchFactory struct {
 	// Must be first member in the struct due to Golang ARM/x86 32-bit
 	// requirements with atomic accesses
 	handlerCounter uint64
 
+	// iFactory and informers are set once by NewWatchFactory and read-only after that
 	iFactory  informerfactory.SharedInformerFactory
 	informers map[reflect.Type]*informer
+
+	// mu guards dynamicClient, dynamicFactory and customInformers
+	mu              sync.Mutex
+	dynamicClient   dynamic.Interface
+	dynamicFactory  dynamicinformer.DynamicSharedInformerFactory
+	customInformers map[schema.GroupVersionResource]*informer
+	stopChan        chan struct{}
 }
 
 const (
@@ -327,16 +482,21 @@ var (
 	nodeType      reflect.Type = reflect.TypeOf(&kapi.Node{})
 )
 
-// NewWatchFactory initializes a new watch factory
-func NewWatchFactory(c kubernetes.Interface, stopChan chan struct{}) (*WatchFactory, error) {
+// NewWatchFactory initializes a new watch factory. dynamicClient backs RegisterCustomResource and may be nil if unused.
+//
+// NOTE: breaking change — adds the dynamicClient parameter; existing call sites must pass nil if they don't use RegisterCustomResource.
+func NewWatchFactory(c kubernetes.Interface, dynamicClient dynamic.Interface, stopChan chan struct{}) (*WatchFactory, error) {
 	// resync time is 12 hours, none of the resources being watched in ovn-kubernetes have
 	// any race condition where a resync may be required e.g. cni executable on node watching for
 	// events on pods and assuming that an 'ADD' event will contain the annotations put in by
 	// ovnkube master (currently, it is just a 'get' loop)
 	// the downside of making it tight (like 10 minutes) is needless spinning on all resources
 	wf := &WatchFactory{
-		iFactory:  informerfactory.NewSharedInformerFactory(c, resyncInterval),
-		informers: make(map[reflect.Type]*informer),
+		iFactory:        informerfactory.NewSharedInformerFactory(c, resyncInterval),
+		informers:       make(map[reflect.Type]*informer),
+		dynamicClient:   dynamicClient,
+		customInformers: make(map[schema.GroupVersionResource]*informer),
+		stopChan:        stopChan,
 	}
 
 	// Create shared informers we know we'll use
@@ -345,7 +505,9 @@ func NewWatchFactory(c kubernetes.Interface, stopChan chan struct{}) (*WatchFact
 	wf.informers[endpointsType] = newInformer(endpointsType, wf.iFactory.Core().V1().Endpoints().Informer())
 	wf.informers[policyType] = newInformer(policyType, wf.iFactory.Networking().V1().NetworkPolicies().Informer())
 	wf.informers[namespaceType] = newInformer(namespaceType, wf.iFactory.Core().V1().Namespaces().Informer())
-	wf.informers[nodeType] = newQueuedInformer(nodeType, wf.iFactory.Core().V1().Nodes().Informer(), stopChan)
+	// Node hasn't migrated to the workqueue-based newQueuedInformer yet, so
+	// it keeps using the legacy hashed-channel path for now.
+	wf.informers[nodeType] = newHashedQueuedInformer(nodeType, wf.iFactory.Core().V1().Nodes().Informer(), stopChan)
 
 	wf.iFactory.Start(stopChan)
 	for oType, synced := range wf.iFactory.WaitForCacheSync(stopChan) {
@@ -361,6 +523,12 @@ func NewWatchFactory(c kubernetes.Interface, stopChan chan struct{}) (*WatchFact
 		for _, inf := range wf.informers {
 			inf.shutdown()
 		}
+
+		wf.mu.Lock()
+		for _, inf := range wf.customInformers {
+			inf.shutdown()
+		}
+		wf.mu.Unlock()
 	}()
 
 	return wf, nil
@@ -392,6 +560,18 @@ func getObjectMeta(objType reflect.Type, obj interface{}) (*metav1.ObjectMeta, e
 		if node, ok := obj.(*kapi.Node); ok {
 			return &node.ObjectMeta, nil
 		}
+	default:
+		// Custom resources registered through RegisterCustomResource,
+		// including *unstructured.Unstructured, don't have a case above;
+		// fall through to the generic accessor instead of requiring every
+		// GVR to be special-cased here.
+		if accessor, err := meta.Accessor(obj); err == nil {
+			return &metav1.ObjectMeta{
+				Namespace: accessor.GetNamespace(),
+				Name:      accessor.GetName(),
+				Labels:    accessor.GetLabels(),
+			}, nil
+		}
 	}
 	return nil, fmt.Errorf("cannot get ObjectMeta from type %v", objType)
 }
@@ -401,6 +581,12 @@ func (wf *WatchFactory) addHandler(objType reflect.Type, namespace string, lsel
 	if !ok {
 		return nil, fmt.Errorf("unknown object type %v", objType)
 	}
+	return wf.addHandlerToInformer(inf, namespace, lsel, funcs, processExisting)
+}
+
+// addHandlerToInformer does the filtering, dispatch and existing-item replay work shared by addHandler and AddCustomResourceHandler
+func (wf *WatchFactory) addHandlerToInformer(inf *informer, namespace string, lsel *metav1.LabelSelector, funcs cache.ResourceEventHandler, processExisting func([]interface{})) (*Handler, error) {
+	objType := inf.oType
 
 	sel, err := metav1.LabelSelectorAsSelector(lsel)
 	if err != nil {
@@ -414,7 +600,7 @@ func (wf *WatchFactory) addHandler(objType reflect.Type, namespace string, lsel
 		}
 		meta, err := getObjectMeta(objType, obj)
 		if err != nil {
-			logrus.Errorf("watch handler filter error: %v", err)
+			handleError(newWatchError(objType, "filter", err))
 			return false
 		}
 		if namespace != "" && meta.Namespace != namespace {
@@ -460,6 +646,76 @@ func (wf *WatchFactory) removeHandler(objType reflect.Type, handler *Handler) er
 	return fmt.Errorf("tried to remove unknown object type %v event handler", objType)
 }
 
+// RegisterCustomResource registers a watch for gvr, keyed by gvr rather than exampleObj's reflect.Type
+func (wf *WatchFactory) RegisterCustomResource(gvr schema.GroupVersionResource, exampleObj runtime.Object) error {
+	return wf.registerCustomResource(gvr, exampleObj, newInformer)
+}
+
+// RegisterCustomResourceWithSyncHandler is RegisterCustomResource, but retries failing keys via syncHandler
+func (wf *WatchFactory) RegisterCustomResourceWithSyncHandler(gvr schema.GroupVersionResource, exampleObj runtime.Object, workers int, maxRetries int, syncHandler SyncHandler) error {
+	return wf.registerCustomResource(gvr, exampleObj, func(objType reflect.Type, sharedInformer cache.SharedIndexInformer) *informer {
+		return newQueuedInformer(objType, sharedInformer, wf.stopChan, workers, maxRetries, syncHandler)
+	})
+}
+
+// registerCustomResource holds the lazy dynamic-informer-factory setup shared by the two RegisterCustomResource* variants
+func (wf *WatchFactory) registerCustomResource(gvr schema.GroupVersionResource, exampleObj runtime.Object, newCustomInformer func(reflect.Type, cache.SharedIndexInformer) *informer) error {
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+
+	if _, ok := wf.customInformers[gvr]; ok {
+		return fmt.Errorf("custom resource %v is already registered", gvr)
+	}
+
+	if wf.dynamicFactory == nil {
+		if wf.dynamicClient == nil {
+			return fmt.Errorf("cannot register custom resource %v: no dynamic client configured", gvr)
+		}
+		wf.dynamicFactory = dynamicinformer.NewDynamicSharedInformerFactory(wf.dynamicClient, resyncInterval)
+	}
+
+	objType := reflect.TypeOf(exampleObj)
+	sharedInformer := wf.dynamicFactory.ForResource(gvr).Informer()
+	wf.customInformers[gvr] = newCustomInformer(objType, sharedInformer)
+
+	wf.dynamicFactory.Start(wf.stopChan)
+	for t, synced := range wf.dynamicFactory.WaitForCacheSync(wf.stopChan) {
+		if !synced {
+			delete(wf.customInformers, gvr)
+			return fmt.Errorf("error in syncing cache for %v custom resource informer", t)
+		}
+	}
+
+	return nil
+}
+
+// AddCustomResourceHandler adds a handler function that will be executed on changes to the custom resource gvr
+func (wf *WatchFactory) AddCustomResourceHandler(gvr schema.GroupVersionResource, namespace string, lsel *metav1.LabelSelector, funcs cache.ResourceEventHandler, processExisting func([]interface{})) (*Handler, error) {
+	wf.mu.Lock()
+	inf, ok := wf.customInformers[gvr]
+	wf.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown custom resource %v: call RegisterCustomResource first", gvr)
+	}
+	return wf.addHandlerToInformer(inf, namespace, lsel, funcs, processExisting)
+}
+
+// RemoveCustomResourceHandler removes a custom resource event handler function
+func (wf *WatchFactory) RemoveCustomResourceHandler(gvr schema.GroupVersionResource, handler *Handler) error {
+	wf.mu.Lock()
+	inf, ok := wf.customInformers[gvr]
+	wf.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown custom resource %v", gvr)
+	}
+	return inf.removeHandler(handler)
+}
+
+// SetErrorHandlers installs additional entries into utilruntime.ErrorHandlers
+func (wf *WatchFactory) SetErrorHandlers(handlers []func(error)) {
+	utilruntime.ErrorHandlers = append(utilruntime.ErrorHandlers, handlers...)
+}
+
 // AddPodHandler adds a handler function that will be executed on Pod object changes
 func (wf *WatchFactory) AddPodHandler(handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) (*Handler, error) {
 	return wf.addHandler(podType, "", nil, handlerFuncs, processExisting)